@@ -0,0 +1,257 @@
+package glhf
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// skylineNode is one segment of a TextureAtlas's skyline: the region [x, x+width) of the atlas
+// is currently filled up to height y.
+type skylineNode struct {
+	x, y, w int
+}
+
+// rect is the mutable, shared packed position of a Region. Grow repacks regions in place by
+// updating the rect through this pointer, rather than handing out a new one, so that every
+// Region value a caller already holds keeps pointing at the right place in the atlas.
+type rect struct {
+	x, y, w, h int
+}
+
+// Region is a named rectangle packed into a TextureAtlas. A Region stays valid across calls to
+// Grow: its coordinates are updated in place as the atlas is repacked, so there's no need to
+// re-fetch it via TextureAtlas.Region afterwards.
+type Region struct {
+	atlas *TextureAtlas
+	rect  *rect
+}
+
+// UV returns the region's texture coordinates within its atlas as (u0, v0, u1, v1).
+func (r Region) UV() mgl32.Vec4 {
+	width, height := float32(r.atlas.width), float32(r.atlas.height)
+	return mgl32.Vec4{
+		float32(r.rect.x) / width,
+		float32(r.rect.y) / height,
+		float32(r.rect.x+r.rect.w) / width,
+		float32(r.rect.y+r.rect.h) / height,
+	}
+}
+
+// Width returns the width of the Region in pixels.
+func (r Region) Width() int {
+	return r.rect.w
+}
+
+// Height returns the height of the Region in pixels.
+func (r Region) Height() int {
+	return r.rect.h
+}
+
+// TextureAtlas packs many named sub-images into a single backing Texture using a skyline bin
+// packer, so that sprites and glyphs sharing an atlas can be batched into a single draw call.
+type TextureAtlas struct {
+	tex    *Texture
+	width  int
+	height int
+	nodes  []skylineNode
+
+	regions map[string]*rect
+	images  map[string]*image.RGBA
+	dirty   []string
+}
+
+// NewTextureAtlas creates an empty TextureAtlas backed by a width x height Texture.
+func NewTextureAtlas(width, height int, smooth bool) *TextureAtlas {
+	return &TextureAtlas{
+		tex:     NewTextureFormat(width, height, 0, RGBA8, smooth, nil),
+		width:   width,
+		height:  height,
+		nodes:   []skylineNode{{x: 0, y: 0, w: width}},
+		regions: make(map[string]*rect),
+		images:  make(map[string]*image.RGBA),
+	}
+}
+
+// Texture returns the Texture backing the atlas.
+func (a *TextureAtlas) Texture() *Texture {
+	return a.tex
+}
+
+// Region returns the named Region previously returned by Add, and whether it exists.
+func (a *TextureAtlas) Region(name string) (Region, bool) {
+	r, ok := a.regions[name]
+	if !ok {
+		return Region{}, false
+	}
+	return Region{atlas: a, rect: r}, true
+}
+
+// Add packs img into the atlas under name and returns its Region. The uploaded pixels are not
+// visible in the Texture until Commit is called. Add fails if the atlas has no room left for
+// img; call Grow and Add again in that case.
+//
+// If name was already added, its existing Region is repacked and updated in place rather than
+// replaced, so any Region value the caller is still holding for it stays correct.
+func (a *TextureAtlas) Add(name string, img *image.RGBA) (Region, error) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	x, y, ok := a.fit(w, h)
+	if !ok {
+		return Region{}, fmt.Errorf("textureatlas: no %dx%d space left for region %q", w, h, name)
+	}
+
+	a.insertNode(x, y+h, w)
+	a.mergeNodes()
+
+	r, exists := a.regions[name]
+	if !exists {
+		r = &rect{}
+		a.regions[name] = r
+	}
+	r.x, r.y, r.w, r.h = x, y, w, h
+
+	a.images[name] = img
+	a.dirty = append(a.dirty, name)
+
+	return Region{atlas: a, rect: r}, nil
+}
+
+// Commit uploads the pixels of every Region added (or re-added by Grow) since the last Commit.
+func (a *TextureAtlas) Commit() {
+	a.tex.Begin()
+	defer a.tex.End()
+
+	for _, name := range a.dirty {
+		r := a.regions[name]
+		a.tex.SetPixels(r.x, r.y, r.w, r.h, tightRGBA(a.images[name]))
+	}
+	a.dirty = a.dirty[:0]
+}
+
+// Grow reallocates the atlas at double its current size and re-packs every existing Region into
+// the new Texture, marking them all dirty again. Call Commit afterwards to upload the result.
+//
+// Every Region previously returned by Add or Region is updated in place by the repack, so it
+// remains valid to use (there is no need, and no way, to invalidate it): its atlas pointer is
+// unchanged and its rect is repacked rather than replaced.
+func (a *TextureAtlas) Grow() {
+	names := make([]string, 0, len(a.regions))
+	for name := range a.regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	images := a.images
+	smooth := a.tex.Smooth()
+
+	a.width *= 2
+	a.height *= 2
+	a.nodes = []skylineNode{{x: 0, y: 0, w: a.width}}
+	a.images = make(map[string]*image.RGBA)
+	a.dirty = nil
+	a.tex = NewTextureFormat(a.width, a.height, 0, RGBA8, smooth, nil)
+
+	for _, name := range names {
+		if _, err := a.Add(name, images[name]); err != nil {
+			panic(fmt.Errorf("textureatlas: grow: region %q no longer fits: %w", name, err))
+		}
+	}
+}
+
+// fit finds the leftmost position at which a w x h rectangle can be placed with the smallest
+// resulting y, as in a skyline/shelf bin packer.
+func (a *TextureAtlas) fit(w, h int) (x, y int, ok bool) {
+	bestY := -1
+	bestX := -1
+
+	for i := range a.nodes {
+		nodeX := a.nodes[i].x
+		if nodeX+w > a.width {
+			break // nodes are sorted by x, so nothing further to the right will fit either
+		}
+
+		spanY := -1
+		widthLeft := w
+		for j := i; widthLeft > 0; j++ {
+			if j >= len(a.nodes) {
+				spanY = -1
+				break
+			}
+			if a.nodes[j].y > spanY {
+				spanY = a.nodes[j].y
+			}
+			widthLeft -= a.nodes[j].w
+		}
+		if spanY < 0 || spanY+h > a.height {
+			continue
+		}
+
+		if bestY == -1 || spanY < bestY || (spanY == bestY && nodeX < bestX) {
+			bestY = spanY
+			bestX = nodeX
+		}
+	}
+
+	if bestY == -1 {
+		return 0, 0, false
+	}
+	return bestX, bestY, true
+}
+
+// insertNode splices a new skyline node (x, y, w) in, trimming or removing any existing nodes
+// it overlaps.
+func (a *TextureAtlas) insertNode(x, y, w int) {
+	idx := 0
+	for idx < len(a.nodes) && a.nodes[idx].x < x {
+		idx++
+	}
+
+	a.nodes = append(a.nodes, skylineNode{})
+	copy(a.nodes[idx+1:], a.nodes[idx:])
+	a.nodes[idx] = skylineNode{x: x, y: y, w: w}
+
+	for i := idx + 1; i < len(a.nodes); {
+		if a.nodes[i].x >= x+w {
+			break
+		}
+		shrink := x + w - a.nodes[i].x
+		if a.nodes[i].w <= shrink {
+			a.nodes = append(a.nodes[:i], a.nodes[i+1:]...)
+			continue
+		}
+		a.nodes[i].x += shrink
+		a.nodes[i].w -= shrink
+		break
+	}
+}
+
+// mergeNodes merges adjacent skyline nodes that share the same height.
+func (a *TextureAtlas) mergeNodes() {
+	for i := 0; i < len(a.nodes)-1; {
+		if a.nodes[i].y == a.nodes[i+1].y {
+			a.nodes[i].w += a.nodes[i+1].w
+			a.nodes = append(a.nodes[:i+1], a.nodes[i+2:]...)
+		} else {
+			i++
+		}
+	}
+}
+
+// tightRGBA returns img's pixels as a tightly packed RGBA byte sequence, copying row by row if
+// img is a sub-image with padding or a non-zero origin.
+func tightRGBA(img *image.RGBA) []uint8 {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if img.Stride == w*4 && img.Rect.Min.X == 0 && img.Rect.Min.Y == 0 {
+		return img.Pix
+	}
+
+	pixels := make([]uint8, w*h*4)
+	for row := 0; row < h; row++ {
+		start := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+row)
+		copy(pixels[row*w*4:(row+1)*w*4], img.Pix[start:start+w*4])
+	}
+	return pixels
+}
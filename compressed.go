@@ -0,0 +1,154 @@
+package glhf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// CompressedFormat is a GPU block-compressed internal texture format.
+type CompressedFormat int32
+
+const (
+	DXT1       = CompressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT1_EXT)
+	DXT3       = CompressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT3_EXT)
+	DXT5       = CompressedFormat(gl.COMPRESSED_RGBA_S3TC_DXT5_EXT)
+	ETC2_RGB8  = CompressedFormat(gl.COMPRESSED_RGB8_ETC2)
+	ETC2_RGBA8 = CompressedFormat(gl.COMPRESSED_RGBA8_ETC2_EAC)
+	ASTC_4x4   = CompressedFormat(gl.COMPRESSED_RGBA_ASTC_4x4_KHR)
+)
+
+// NewCompressedTexture creates a new texture holding GPU-compressed (block-compressed) pixel
+// data, such as DXT/BCn, ETC2 or ASTC. mipLevels holds one already-compressed blob per mip
+// level, starting at the full width x height level 0; the Texture's mipmap level count is its
+// length minus one (level 0 is not counted), so a single blob gives a texture without mipmaps.
+func NewCompressedTexture(width, height int, format CompressedFormat, mipLevels [][]byte) *Texture {
+	tex := &Texture{
+		tex: binder{
+			restoreLoc: gl.TEXTURE_BINDING_2D,
+			bindFunc: func(obj uint32) {
+				gl.BindTexture(gl.TEXTURE_2D, obj)
+			},
+		},
+		width:        width,
+		height:       height,
+		mipmapLevels: len(mipLevels) - 1,
+		compressed:   true,
+	}
+
+	gl.GenTextures(1, &tex.tex.obj)
+
+	tex.Begin()
+	defer tex.End()
+
+	w, h := width, height
+	for level, data := range mipLevels {
+		gl.CompressedTexImage2D(
+			gl.TEXTURE_2D,
+			int32(level),
+			uint32(format),
+			int32(w),
+			int32(h),
+			0,
+			int32(len(data)),
+			gl.Ptr(data),
+		)
+
+		if w > 1 {
+			w /= 2
+		}
+		if h > 1 {
+			h /= 2
+		}
+	}
+
+	tex.SetSmooth(len(mipLevels) > 1)
+	tex.SetWrap(CLAMP_TO_EDGE)
+
+	runtime.SetFinalizer(tex, (*Texture).delete)
+
+	return tex
+}
+
+// ktxIdentifier is the fixed 12-byte magic at the start of every KTX1 file.
+var ktxIdentifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+// ktxHeader is the fixed-size header of a KTX1 container, as laid out in the KTX 1.0 spec.
+type ktxHeader struct {
+	Identifier            [12]byte
+	Endianness            uint32
+	GlType                uint32
+	GlTypeSize            uint32
+	GlFormat              uint32
+	GlInternalFormat      uint32
+	GlBaseInternalFormat  uint32
+	PixelWidth            uint32
+	PixelHeight           uint32
+	PixelDepth            uint32
+	NumberOfArrayElements uint32
+	NumberOfFaces         uint32
+	NumberOfMipmapLevels  uint32
+	BytesOfKeyValueData   uint32
+}
+
+// LoadKTX reads a KTX1 container holding a single, non-array, non-cubemap compressed 2D
+// texture and uploads it via NewCompressedTexture.
+func LoadKTX(r io.Reader) (*Texture, error) {
+	var header ktxHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("ktx: read header: %w", err)
+	}
+	if header.Identifier != ktxIdentifier {
+		return nil, fmt.Errorf("ktx: not a KTX1 file")
+	}
+	if header.Endianness != 0x04030201 {
+		return nil, fmt.Errorf("ktx: big-endian KTX files are not supported")
+	}
+	if header.GlType != 0 || header.GlFormat != 0 {
+		return nil, fmt.Errorf("ktx: only compressed textures are supported, glType/glFormat must be 0")
+	}
+	if header.NumberOfFaces != 1 || header.NumberOfArrayElements > 1 || header.PixelDepth > 1 {
+		return nil, fmt.Errorf("ktx: only single-face, non-array, 2D textures are supported")
+	}
+
+	if header.BytesOfKeyValueData > 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(header.BytesOfKeyValueData)); err != nil {
+			return nil, fmt.Errorf("ktx: skip key/value data: %w", err)
+		}
+	}
+
+	levels := header.NumberOfMipmapLevels
+	if levels == 0 {
+		levels = 1
+	}
+
+	mipLevels := make([][]byte, 0, levels)
+	for level := uint32(0); level < levels; level++ {
+		var imageSize uint32
+		if err := binary.Read(r, binary.LittleEndian, &imageSize); err != nil {
+			return nil, fmt.Errorf("ktx: read mip level %d size: %w", level, err)
+		}
+
+		data := make([]byte, imageSize)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("ktx: read mip level %d data: %w", level, err)
+		}
+		mipLevels = append(mipLevels, data)
+
+		if pad := (4 - imageSize%4) % 4; pad != 0 {
+			if _, err := io.CopyN(io.Discard, r, int64(pad)); err != nil {
+				return nil, fmt.Errorf("ktx: skip mip level %d padding: %w", level, err)
+			}
+		}
+	}
+
+	return NewCompressedTexture(
+		int(header.PixelWidth),
+		int(header.PixelHeight),
+		CompressedFormat(header.GlInternalFormat),
+		mipLevels,
+	), nil
+}
@@ -0,0 +1,110 @@
+package glhf
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Format specifies the internal representation used to store a Texture's pixel data on the
+// GPU.
+type Format int
+
+const (
+	RGBA8 Format = iota
+	RGBA16F
+	RGBA32F
+	R8
+	RG8
+	R16F
+	DEPTH24
+	DEPTH24_STENCIL8
+	SRGB8_ALPHA8
+)
+
+// formatInfo describes how a Format maps onto the arguments of glTexImage2D/glTexSubImage2D.
+type formatInfo struct {
+	internalFormat int32
+	baseFormat     uint32
+	channels       int
+}
+
+var formats = map[Format]formatInfo{
+	RGBA8:            {gl.RGBA8, gl.RGBA, 4},
+	RGBA16F:          {gl.RGBA16F, gl.RGBA, 4},
+	RGBA32F:          {gl.RGBA32F, gl.RGBA, 4},
+	R8:               {gl.R8, gl.RED, 1},
+	RG8:              {gl.RG8, gl.RG, 2},
+	R16F:             {gl.R16F, gl.RED, 1},
+	DEPTH24:          {gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT, 1},
+	DEPTH24_STENCIL8: {gl.DEPTH24_STENCIL8, gl.DEPTH_STENCIL, 1},
+	SRGB8_ALPHA8:     {gl.SRGB8_ALPHA8, gl.RGBA, 4},
+}
+
+func (f Format) info() formatInfo {
+	info, ok := formats[f]
+	if !ok {
+		panic(fmt.Errorf("texture: unknown format %d", f))
+	}
+	return info
+}
+
+// pixelType returns the OpenGL type constant matching the concrete type of pixels. pixels may
+// be nil, in which case no data is uploaded and UNSIGNED_BYTE is assumed.
+func pixelType(pixels interface{}) uint32 {
+	switch pixels.(type) {
+	case nil:
+		return gl.UNSIGNED_BYTE
+	case []uint8:
+		return gl.UNSIGNED_BYTE
+	case []uint16:
+		return gl.UNSIGNED_SHORT
+	case []float32:
+		return gl.FLOAT
+	default:
+		panic(fmt.Errorf("texture: unsupported pixel data type %T", pixels))
+	}
+}
+
+// pixelElemSize returns the size in bytes of a single element of pixels.
+func pixelElemSize(pixels interface{}) int {
+	switch pixels.(type) {
+	case []uint8:
+		return 1
+	case []uint16:
+		return 2
+	case []float32:
+		return 4
+	default:
+		panic(fmt.Errorf("texture: unsupported pixel data type %T", pixels))
+	}
+}
+
+// readbackType returns the OpenGL type and per-element byte size used to read format's pixels
+// back from the GPU.
+func (f Format) readbackType() (glType uint32, elemSize int) {
+	switch f {
+	case RGBA8, R8, RG8, SRGB8_ALPHA8:
+		return gl.UNSIGNED_BYTE, 1
+	case DEPTH24_STENCIL8:
+		return gl.UNSIGNED_INT_24_8, 4
+	default:
+		return gl.FLOAT, 4
+	}
+}
+
+// pixelLen returns the number of elements (not bytes) stored in pixels.
+func pixelLen(pixels interface{}) int {
+	switch p := pixels.(type) {
+	case nil:
+		return 0
+	case []uint8:
+		return len(p)
+	case []uint16:
+		return len(p)
+	case []float32:
+		return len(p)
+	default:
+		panic(fmt.Errorf("texture: unsupported pixel data type %T", pixels))
+	}
+}
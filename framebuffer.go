@@ -0,0 +1,162 @@
+package glhf
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/faiface/mainthread"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Framebuffer is an OpenGL framebuffer object. It's used for rendering to a Texture
+// (or a set of Textures) instead of directly to the screen.
+type Framebuffer struct {
+	fbo    binder
+	rbo    uint32
+	color  []*Texture
+	width  int
+	height int
+}
+
+// NewFramebuffer creates a new Framebuffer with the given color attachment. If withDepth is
+// true, a combined depth/stencil renderbuffer matching the Texture's dimensions is also
+// allocated and attached.
+//
+// The Framebuffer must be bound (Begin) before drawing into it; AttachColor and DrawBuffers bind
+// it themselves. Its completeness is validated immediately.
+func NewFramebuffer(color *Texture, withDepth bool) *Framebuffer {
+	fb := &Framebuffer{
+		fbo: binder{
+			restoreLoc: gl.FRAMEBUFFER_BINDING,
+			bindFunc: func(obj uint32) {
+				gl.BindFramebuffer(gl.FRAMEBUFFER, obj)
+			},
+		},
+		color:  []*Texture{color},
+		width:  color.width,
+		height: color.height,
+	}
+
+	gl.GenFramebuffers(1, &fb.fbo.obj)
+
+	fb.Begin()
+	defer fb.End()
+
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, color.ID(), 0)
+
+	if withDepth {
+		gl.GenRenderbuffers(1, &fb.rbo)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, fb.rbo)
+		gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, int32(fb.width), int32(fb.height))
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, fb.rbo)
+	}
+
+	fb.checkComplete()
+
+	runtime.SetFinalizer(fb, (*Framebuffer).delete)
+
+	return fb
+}
+
+func (f *Framebuffer) delete() {
+	mainthread.CallNonBlock(func() {
+		gl.DeleteFramebuffers(1, &f.fbo.obj)
+		if f.rbo != 0 {
+			gl.DeleteRenderbuffers(1, &f.rbo)
+		}
+	})
+}
+
+// checkComplete validates the Framebuffer with glCheckFramebufferStatus. The Framebuffer must
+// already be bound. It panics if the framebuffer is not complete.
+func (f *Framebuffer) checkComplete() {
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Errorf("framebuffer: incomplete, status: 0x%x", status))
+	}
+}
+
+// AttachColor attaches an additional color Texture to this Framebuffer at the next free color
+// attachment slot. Call DrawBuffers afterwards to enable rendering into it.
+func (f *Framebuffer) AttachColor(color *Texture) {
+	f.Begin()
+	defer f.End()
+
+	index := uint32(len(f.color))
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0+index, gl.TEXTURE_2D, color.ID(), 0)
+	f.color = append(f.color, color)
+
+	f.checkComplete()
+}
+
+// DrawBuffers enables multiple render targets (MRT) by specifying which color attachments are
+// written to by fragment shader outputs. attachments are GL_COLOR_ATTACHMENTi values, e.g.
+// gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1, ...
+func (f *Framebuffer) DrawBuffers(attachments []uint32) {
+	f.Begin()
+	defer f.End()
+
+	if len(attachments) == 0 {
+		gl.DrawBuffers(0, nil)
+		return
+	}
+	gl.DrawBuffers(int32(len(attachments)), &attachments[0])
+}
+
+// Texture returns the color Texture attached at the given index (0 being the one passed to
+// NewFramebuffer).
+func (f *Framebuffer) Texture(index int) *Texture {
+	return f.color[index]
+}
+
+// Width returns the width of the Framebuffer in pixels.
+func (f *Framebuffer) Width() int {
+	return f.width
+}
+
+// Height returns the height of the Framebuffer in pixels.
+func (f *Framebuffer) Height() int {
+	return f.height
+}
+
+// ID returns the OpenGL ID of this Framebuffer.
+func (f *Framebuffer) ID() uint32 {
+	return f.fbo.obj
+}
+
+// Begin binds the Framebuffer as the current render target. This is necessary before drawing
+// into it.
+func (f *Framebuffer) Begin() {
+	f.fbo.bind()
+}
+
+// End unbinds the Framebuffer and restores the previous one.
+func (f *Framebuffer) End() {
+	f.fbo.restore()
+}
+
+// Blit copies the color attachment of this Framebuffer into dst (or onto the screen, if dst is
+// nil) using glBlitFramebuffer. srcRect and dstRect are [x0, y0, x1, y1] pixel rectangles.
+// filter is NEAREST or LINEAR and is used when srcRect and dstRect differ in size. The
+// previously bound read/draw framebuffers are restored before Blit returns.
+func (f *Framebuffer) Blit(dst *Framebuffer, srcRect, dstRect [4]int, filter Filter) {
+	var prevRead, prevDraw int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prevRead)
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &prevDraw)
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.fbo.obj)
+
+	var dstID uint32
+	if dst != nil {
+		dstID = dst.fbo.obj
+	}
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dstID)
+
+	gl.BlitFramebuffer(
+		int32(srcRect[0]), int32(srcRect[1]), int32(srcRect[2]), int32(srcRect[3]),
+		int32(dstRect[0]), int32(dstRect[1]), int32(dstRect[2]), int32(dstRect[3]),
+		gl.COLOR_BUFFER_BIT, uint32(filter),
+	)
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prevRead))
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(prevDraw))
+}
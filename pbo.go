@@ -0,0 +1,188 @@
+package glhf
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// pboRingSize is the number of Pixel Buffer Objects kept per Texture per direction (read/write).
+// Cycling through a small ring of already-allocated buffers, rather than creating and destroying
+// one per call, is what lets BeginRead/SetPixelsAsync avoid a GPU stall: the driver can still be
+// copying into (or out of) a buffer from a couple of calls ago while a new transfer starts into
+// the next one in the ring.
+const pboRingSize = 3
+
+// pboRing is a fixed-size, lazily-allocated ring of PBOs belonging to a single Texture.
+type pboRing struct {
+	bufs [pboRingSize]uint32
+	next int
+	live bool
+}
+
+// get returns the next PBO in the ring, allocating the ring's buffers on first use.
+func (r *pboRing) get() uint32 {
+	if !r.live {
+		gl.GenBuffers(pboRingSize, &r.bufs[0])
+		r.live = true
+	}
+
+	buf := r.bufs[r.next]
+	r.next = (r.next + 1) % pboRingSize
+	return buf
+}
+
+func (r *pboRing) delete() {
+	if r.live {
+		gl.DeleteBuffers(pboRingSize, &r.bufs[0])
+		r.live = false
+	}
+}
+
+// PixelReadRequest is an in-flight, asynchronous readback of a rectangular region of a Texture,
+// started by Texture.BeginRead. The GPU copy happens into one of the Texture's read PBOs,
+// guarded by a fence sync, so the caller isn't stalled waiting for it the way a synchronous
+// glGetTexImage would stall it.
+type PixelReadRequest struct {
+	pbo    uint32
+	sync   uintptr
+	format uint32
+	glType uint32
+	size   int
+}
+
+// BeginRead starts an asynchronous readback of the x,y,w,h region of the Texture. Call Poll or
+// Wait on the returned PixelReadRequest to retrieve the pixels once the GPU has finished.
+func (t *Texture) BeginRead(x, y, w, h int) *PixelReadRequest {
+	if t.compressed {
+		panic("begin read: texture uses a compressed format")
+	}
+	info := t.format.info()
+	glType, elemSize := t.format.readbackType()
+
+	req := &PixelReadRequest{
+		pbo:    t.readPBOs.get(),
+		format: info.baseFormat,
+		glType: glType,
+		size:   w * h * info.channels * elemSize,
+	}
+
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, req.pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, req.size, nil, gl.STREAM_READ)
+
+	var prevRead int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prevRead)
+
+	var fbo uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, readAttachment(t.format), gl.TEXTURE_2D, t.ID(), 0)
+
+	if status := gl.CheckFramebufferStatus(gl.READ_FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Errorf("begin read: framebuffer incomplete, status: 0x%x", status))
+	}
+
+	gl.ReadPixels(int32(x), int32(y), int32(w), int32(h), req.format, req.glType, gl.PtrOffset(0))
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prevRead))
+	gl.DeleteFramebuffers(1, &fbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+
+	req.sync = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+
+	return req
+}
+
+// readAttachment returns the framebuffer attachment point a Texture of the given Format must be
+// bound to before it can be read with glReadPixels: depth and depth/stencil formats cannot be
+// attached as a color attachment.
+func readAttachment(format Format) uint32 {
+	switch format {
+	case DEPTH24:
+		return gl.DEPTH_ATTACHMENT
+	case DEPTH24_STENCIL8:
+		return gl.DEPTH_STENCIL_ATTACHMENT
+	default:
+		return gl.COLOR_ATTACHMENT0
+	}
+}
+
+// Poll returns the read pixels and true if the readback has completed, or nil and false if it
+// is still in flight. Once Poll or Wait returns pixels, the PixelReadRequest is spent and must
+// not be polled again.
+func (r *PixelReadRequest) Poll() ([]byte, bool) {
+	switch gl.ClientWaitSync(r.sync, 0, 0) {
+	case gl.ALREADY_SIGNALED, gl.CONDITION_SATISFIED:
+		return r.read(), true
+	case gl.WAIT_FAILED:
+		panic("pixelreadrequest: poll: wait failed")
+	default:
+		return nil, false
+	}
+}
+
+// Wait blocks until the readback has completed and returns the read pixels.
+func (r *PixelReadRequest) Wait() []byte {
+	for {
+		switch gl.ClientWaitSync(r.sync, gl.SYNC_FLUSH_COMMANDS_BIT, 1e9) {
+		case gl.ALREADY_SIGNALED, gl.CONDITION_SATISFIED:
+			return r.read()
+		case gl.WAIT_FAILED:
+			panic("pixelreadrequest: wait: wait failed")
+		}
+	}
+}
+
+// read maps the PBO, copies its contents into Go memory, and releases the fence sync. The PBO
+// itself belongs to its Texture's read ring and is left alive for reuse by a future BeginRead.
+func (r *PixelReadRequest) read() []byte {
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, r.pbo)
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, r.size, gl.MAP_READ_BIT)
+
+	data := make([]byte, r.size)
+	copy(data, unsafe.Slice((*byte)(ptr), r.size))
+
+	gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+
+	gl.DeleteSync(r.sync)
+
+	return data
+}
+
+// SetPixelsAsync streams pixels into a sub-region of the Texture through one of the Texture's
+// write ring's Pixel Unpack Buffer Objects instead of uploading directly from client memory, so
+// the driver can copy it to the GPU without blocking the calling (main) thread on the transfer.
+// pixels follows the same rules as SetPixels.
+func (t *Texture) SetPixelsAsync(x, y, w, h int, pixels interface{}) {
+	if t.compressed {
+		panic("set pixels async: texture uses a compressed format")
+	}
+	info := t.format.info()
+	if pixelLen(pixels) != w*h*info.channels {
+		panic("set pixels async: wrong number of pixels")
+	}
+
+	buf := t.writePBOs.get()
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, buf)
+	gl.BufferData(gl.PIXEL_UNPACK_BUFFER, pixelLen(pixels)*pixelElemSize(pixels), gl.Ptr(pixels), gl.STREAM_DRAW)
+
+	gl.TexSubImage2D(
+		gl.TEXTURE_2D,
+		0,
+		int32(x),
+		int32(y),
+		int32(w),
+		int32(h),
+		info.baseFormat,
+		pixelType(pixels),
+		gl.PtrOffset(0),
+	)
+
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+
+	if t.mipmapLevels > 0 {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+}
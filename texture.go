@@ -1,6 +1,7 @@
 package glhf
 
 import (
+	"fmt"
 	"runtime"
 
 	"github.com/faiface/mainthread"
@@ -16,16 +17,44 @@ const (
 	REPEAT = Wrap(gl.REPEAT)
 )
 
+// Filter is an OpenGL texture minifying or magnifying filter function.
+type Filter int32
+
+const (
+	NEAREST                Filter = Filter(gl.NEAREST)
+	LINEAR                 Filter = Filter(gl.LINEAR)
+	NEAREST_MIPMAP_NEAREST Filter = Filter(gl.NEAREST_MIPMAP_NEAREST)
+	LINEAR_MIPMAP_NEAREST  Filter = Filter(gl.LINEAR_MIPMAP_NEAREST)
+	NEAREST_MIPMAP_LINEAR  Filter = Filter(gl.NEAREST_MIPMAP_LINEAR)
+	LINEAR_MIPMAP_LINEAR   Filter = Filter(gl.LINEAR_MIPMAP_LINEAR)
+)
+
 // Texture is an OpenGL texture.
 type Texture struct {
 	tex           binder
 	width, height, mipmapLevels int
+	format        Format
+	compressed    bool
 	smooth        bool
+	readPBOs      pboRing
+	writePBOs     pboRing
 }
 
 // NewTexture creates a new texture with the specified width and height with some initial
 // pixel values. The pixels must be a sequence of RGBA values (one byte per component).
 func NewTexture(width, height, mipmapLevels int, smooth bool, pixels []uint8) *Texture {
+	return NewTextureFormat(width, height, mipmapLevels, RGBA8, smooth, pixels)
+}
+
+// NewTextureFormat creates a new texture with the specified width, height and internal Format.
+//
+// pixels holds the initial pixel data and must be one of []uint8, []uint16 or []float32,
+// matching the component type expected by format; it may also be nil, in which case the
+// texture's storage is allocated but left uninitialized (useful for render targets such as
+// depth buffers).
+func NewTextureFormat(width, height, mipmapLevels int, format Format, smooth bool, pixels interface{}) *Texture {
+	info := format.info()
+
 	tex := &Texture{
 		tex: binder{
 			restoreLoc: gl.TEXTURE_BINDING_2D,
@@ -33,9 +62,10 @@ func NewTexture(width, height, mipmapLevels int, smooth bool, pixels []uint8) *T
 				gl.BindTexture(gl.TEXTURE_2D, obj)
 			},
 		},
-		width:  width,
-		height: height,
+		width:        width,
+		height:       height,
 		mipmapLevels: mipmapLevels,
+		format:       format,
 	}
 
 	gl.GenTextures(1, &tex.tex.obj)
@@ -44,29 +74,31 @@ func NewTexture(width, height, mipmapLevels int, smooth bool, pixels []uint8) *T
 	defer tex.End()
 
 	if mipmapLevels > 0 {
-		gl.TexStorage2D(gl.TEXTURE_2D, int32(mipmapLevels), gl.RGBA8, int32(width), int32(height))
-		gl.TexSubImage2D(
-			gl.TEXTURE_2D,
-			0,
-			0,
-			0,
-			int32(width),
-			int32(height),
-			gl.RGBA,
-			gl.UNSIGNED_BYTE,
-			gl.Ptr(pixels),
-		)
+		gl.TexStorage2D(gl.TEXTURE_2D, int32(mipmapLevels), uint32(info.internalFormat), int32(width), int32(height))
+		if pixels != nil {
+			gl.TexSubImage2D(
+				gl.TEXTURE_2D,
+				0,
+				0,
+				0,
+				int32(width),
+				int32(height),
+				info.baseFormat,
+				pixelType(pixels),
+				gl.Ptr(pixels),
+			)
+		}
 		gl.GenerateMipmap(gl.TEXTURE_2D)
 	} else {
 		gl.TexImage2D(
 			gl.TEXTURE_2D,
 			0,
-			gl.RGBA,
+			info.internalFormat,
 			int32(width),
 			int32(height),
 			0,
-			gl.RGBA,
-			gl.UNSIGNED_BYTE,
+			info.baseFormat,
+			pixelType(pixels),
 			gl.Ptr(pixels),
 		)
 	}
@@ -85,6 +117,8 @@ func NewTexture(width, height, mipmapLevels int, smooth bool, pixels []uint8) *T
 func (t *Texture) delete() {
 	mainthread.CallNonBlock(func() {
 		gl.DeleteTextures(1, &t.tex.obj)
+		t.readPBOs.delete()
+		t.writePBOs.delete()
 	})
 }
 
@@ -103,9 +137,16 @@ func (t *Texture) Height() int {
 	return t.height
 }
 
-// SetPixels sets the content of a sub-region of the Texture. Pixels must be an RGBA byte sequence.
-func (t *Texture) SetPixels(x, y, w, h int, pixels []uint8) {
-	if len(pixels) != w*h*4 {
+// SetPixels sets the content of a sub-region of the Texture. pixels must be one of []uint8,
+// []uint16 or []float32, matching the component type of the Texture's Format, and must contain
+// exactly w*h*channels elements, where channels is the number of components of the Format.
+func (t *Texture) SetPixels(x, y, w, h int, pixels interface{}) {
+	if t.compressed {
+		panic("set pixels: texture uses a compressed format, use NewCompressedTexture instead")
+	}
+	info := t.format.info()
+
+	if pixelLen(pixels) != w*h*info.channels {
 		panic("set pixels: wrong number of pixels")
 	}
 	gl.TexSubImage2D(
@@ -115,8 +156,8 @@ func (t *Texture) SetPixels(x, y, w, h int, pixels []uint8) {
 		int32(y),
 		int32(w),
 		int32(h),
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
+		info.baseFormat,
+		pixelType(pixels),
 		gl.Ptr(pixels),
 	)
 
@@ -126,23 +167,60 @@ func (t *Texture) SetPixels(x, y, w, h int, pixels []uint8) {
 
 }
 
-// Pixels returns the content of a sub-region of the Texture as an RGBA byte sequence.
-func (t *Texture) Pixels(x, y, w, h int) []uint8 {
-	pixels := make([]uint8, t.width*t.height*4)
-	gl.GetTexImage(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(pixels),
-	)
-	subPixels := make([]uint8, w*h*4)
-	for i := 0; i < h; i++ {
-		row := pixels[(i+y)*t.width*4+x*4 : (i+y)*t.width*4+(x+w)*4]
-		subRow := subPixels[i*w*4 : (i+1)*w*4]
-		copy(subRow, row)
+// Pixels returns the content of a sub-region of the Texture. The concrete type of the returned
+// value matches the component type of the Texture's Format: []uint8 for the 8-bit formats,
+// []uint32 (packed 24-bit depth and 8-bit stencil) for DEPTH24_STENCIL8, and []float32 for the
+// floating-point formats and DEPTH24.
+func (t *Texture) Pixels(x, y, w, h int) interface{} {
+	if t.compressed {
+		panic("pixels: texture uses a compressed format")
 	}
-	return subPixels
+	info := t.format.info()
+	glType, elemSize := t.format.readbackType()
+
+	switch {
+	case elemSize == 1:
+		pixels := make([]uint8, t.width*t.height*info.channels)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, info.baseFormat, glType, gl.Ptr(pixels))
+
+		subPixels := make([]uint8, w*h*info.channels)
+		for i := 0; i < h; i++ {
+			row := pixels[(i+y)*t.width*info.channels+x*info.channels : (i+y)*t.width*info.channels+(x+w)*info.channels]
+			subRow := subPixels[i*w*info.channels : (i+1)*w*info.channels]
+			copy(subRow, row)
+		}
+		return subPixels
+	case glType == gl.UNSIGNED_INT_24_8:
+		pixels := make([]uint32, t.width*t.height*info.channels)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, info.baseFormat, glType, gl.Ptr(pixels))
+
+		subPixels := make([]uint32, w*h*info.channels)
+		for i := 0; i < h; i++ {
+			row := pixels[(i+y)*t.width*info.channels+x*info.channels : (i+y)*t.width*info.channels+(x+w)*info.channels]
+			subRow := subPixels[i*w*info.channels : (i+1)*w*info.channels]
+			copy(subRow, row)
+		}
+		return subPixels
+	case elemSize == 4:
+		pixels := make([]float32, t.width*t.height*info.channels)
+		gl.GetTexImage(gl.TEXTURE_2D, 0, info.baseFormat, glType, gl.Ptr(pixels))
+
+		subPixels := make([]float32, w*h*info.channels)
+		for i := 0; i < h; i++ {
+			row := pixels[(i+y)*t.width*info.channels+x*info.channels : (i+y)*t.width*info.channels+(x+w)*info.channels]
+			subRow := subPixels[i*w*info.channels : (i+1)*w*info.channels]
+			copy(subRow, row)
+		}
+		return subPixels
+	default:
+		panic(fmt.Errorf("pixels: unsupported readback element size %d", elemSize))
+	}
+}
+
+// Format returns the internal Format of the Texture. A Texture created by NewCompressedTexture
+// has no Format; for it, Format returns RGBA8, the zero value.
+func (t *Texture) Format() Format {
+	return t.format
 }
 
 // SetSmooth sets whether the Texture should be drawn "smoothly" or "pixely".
@@ -170,11 +248,64 @@ func (t *Texture) SetSmooth(smooth bool) {
 	}
 }
 
+// SetWrap sets both the horizontal and vertical wrap mode of the Texture.
 func (t *Texture) SetWrap(wrap Wrap) {
+	t.SetWrapS(wrap)
+	t.SetWrapT(wrap)
+}
+
+// SetWrapS sets the horizontal (S axis) wrap mode of the Texture.
+func (t *Texture) SetWrapS(wrap Wrap) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, int32(wrap))
+}
+
+// SetWrapT sets the vertical (T axis) wrap mode of the Texture.
+func (t *Texture) SetWrapT(wrap Wrap) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, int32(wrap))
 }
 
+// SetBorderColor sets the color sampled outside of the Texture when its wrap mode is
+// CLAMP_TO_BORDER.
+func (t *Texture) SetBorderColor(color mgl32.Vec4) {
+	gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &color[0])
+}
+
+// SetFilter sets the minifying and magnifying filter functions independently. Prefer SetSmooth
+// for the common case; use SetFilter when e.g. LINEAR magnification needs to be paired with a
+// non-default minification filter such as NEAREST_MIPMAP_LINEAR.
+func (t *Texture) SetFilter(min, mag Filter) {
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, int32(min))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, int32(mag))
+}
+
+// SetAnisotropy sets the degree of anisotropic filtering applied to the Texture, clamped to
+// what the driver reports via GL_MAX_TEXTURE_MAX_ANISOTROPY. Requires the
+// GL_EXT_texture_filter_anisotropic extension (core since OpenGL 4.6).
+func (t *Texture) SetAnisotropy(level float32) {
+	var max float32
+	gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+
+	if level > max {
+		level = max
+	}
+	if level < 1 {
+		level = 1
+	}
+
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAX_ANISOTROPY, level)
+}
+
+// SetLODBias sets the bias added to the mipmap level of detail chosen by the Texture's sampler.
+func (t *Texture) SetLODBias(bias float32) {
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_LOD_BIAS, bias)
+}
+
+// SetLODRange clamps the range of mipmap levels of detail the Texture's sampler may select.
+func (t *Texture) SetLODRange(min, max float32) {
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MIN_LOD, min)
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAX_LOD, max)
+}
+
 // Smooth returns whether the Texture is set to be drawn "smooth" or "pixely".
 func (t *Texture) Smooth() bool {
 	return t.smooth